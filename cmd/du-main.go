@@ -19,7 +19,9 @@ package cmd
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/fatih/color"
@@ -27,6 +29,7 @@ import (
 	json "github.com/minio/mc/pkg/colorjson"
 	"github.com/minio/mc/pkg/console"
 	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio/pkg/wildcard"
 )
 
 // du specific flags.
@@ -36,9 +39,185 @@ var (
 			Name:  "depth, d",
 			Usage: "print the total for a folder prefix only if it is N or fewer levels below the command line argument",
 		},
+		cli.IntFlag{
+			Name:  "parallel, p",
+			Value: 16,
+			Usage: "run up to N directory walkers concurrently",
+		},
+		cli.BoolTFlag{
+			Name:  "apparent-size",
+			Usage: "report logical file size instead of on-disk block usage (pass --apparent-size=false on block-oriented backends such as hdfs:// to see actual space used)",
+		},
+		cli.StringSliceFlag{
+			Name:  "include",
+			Usage: "only count objects matching PATTERN (repeatable; glob syntax, matched against the key relative to TARGET)",
+		},
+		cli.StringSliceFlag{
+			Name:  "exclude",
+			Usage: "skip objects matching PATTERN (repeatable; glob syntax, matched against the key relative to TARGET, takes precedence over --include)",
+		},
+		cli.BoolFlag{
+			Name:  "versions",
+			Usage: "break out non-current versions and delete markers from the current-version total (only meaningful against a backend whose listing actually includes them; du warns once if this one's doesn't)",
+		},
+		cli.BoolFlag{
+			Name:  "by-class",
+			Usage: "print a per-storage-class subtotal under each prefix",
+		},
 	}
 )
 
+// duWalkTimeout bounds how long du() will wait between successive items
+// off a single directory's listing channel before treating that
+// listing as stalled against the backend and giving up on it. It does
+// not bound waiting for a free pool slot: queuing behind other
+// directories is ordinary contention, not a stall.
+const duWalkTimeout = 30 * time.Second
+
+// duJob is one subdirectory handed to duSubmit, tagged with its
+// position among its siblings so results can be reassembled in order.
+type duJob struct {
+	index int
+	alias string
+	depth int
+}
+
+// duStats accumulates a prefix's total size alongside the optional
+// --by-class and --versions breakdowns. Zero value is an empty prefix.
+type duStats struct {
+	Size int64
+
+	// Classes holds the per-storage-class subtotal, populated only
+	// when --by-class is set.
+	Classes map[string]int64
+
+	// Current/NonCurrent/DeleteMarkers are populated only when
+	// --versions is set.
+	Current       int64
+	NonCurrent    int64
+	DeleteMarkers int64
+}
+
+// addFile folds a single object's contribution into s.
+func (s *duStats) addFile(size int64, storageClass string, isLatest, isDeleteMarker bool) {
+	s.Size += size
+
+	if duByClass {
+		if s.Classes == nil {
+			s.Classes = map[string]int64{}
+		}
+		if storageClass == "" {
+			storageClass = "STANDARD"
+		}
+		s.Classes[storageClass] += size
+	}
+
+	if duVersions {
+		switch {
+		case isDeleteMarker:
+			s.DeleteMarkers++
+		case isLatest:
+			s.Current += size
+		default:
+			s.NonCurrent += size
+		}
+	}
+}
+
+// merge folds a child prefix's stats into s.
+func (s *duStats) merge(child duStats) {
+	s.Size += child.Size
+	s.Current += child.Current
+	s.NonCurrent += child.NonCurrent
+	s.DeleteMarkers += child.DeleteMarkers
+	for class, size := range child.Classes {
+		if s.Classes == nil {
+			s.Classes = map[string]int64{}
+		}
+		s.Classes[class] += size
+	}
+}
+
+// duResult is the aggregated stats and pending output for a duJob, or
+// the error that aborted it. Messages are handed back rather than
+// printed from inside the worker so the caller can flush them in
+// submission order once every sibling has reported in.
+type duResult struct {
+	index int
+	stats duStats
+	msgs  []duMessage
+	err   error
+}
+
+// duTreeWalkPool bounds how many directory listings `du` has in flight
+// at once. Unlike a fixed pool of long-lived workers pulling off a
+// shared job queue, it only ever gates the single List() call that
+// reads one directory's immediate children: acquiring a slot never
+// blocks on that directory's descendants, since the recursive walk of
+// each subdirectory happens in its own goroutine acquired *after* the
+// slot for the current directory has already been released. That is
+// what keeps it deadlock-free — a worker that also has to wait on its
+// own children, with both waiter and children drawn from the same
+// bounded slot count, is exactly how a fixed worker pool wedges itself.
+type duTreeWalkPool struct {
+	sem chan struct{}
+}
+
+// newDuTreeWalkPool returns a pool that allows `parallel` directory
+// listings to run concurrently.
+func newDuTreeWalkPool(parallel int) *duTreeWalkPool {
+	if parallel <= 0 {
+		parallel = 1
+	}
+	return &duTreeWalkPool{sem: make(chan struct{}, parallel)}
+}
+
+// acquire reserves a slot for listing a single directory, blocking
+// until one is free. This never waits on anything but another
+// directory's listing finishing, which is always bounded, so there is
+// no deadline here: a caller queued behind a busy pool is making
+// normal progress, not stuck. (duWalkTimeout instead bounds the wait
+// between items of an individual listing, in du(), against a stalled
+// backend.)
+func (p *duTreeWalkPool) acquire() {
+	p.sem <- struct{}{}
+}
+
+// release frees the slot acquired for one directory's listing.
+func (p *duTreeWalkPool) release() {
+	<-p.sem
+}
+
+// duSubmit walks the given subdirectories concurrently, one goroutine
+// per job, and collects their stats and pending messages in the same
+// order the jobs were submitted regardless of completion order, so
+// callers can flush deterministic, depth-ordered output. It does not
+// itself hold a pool slot while waiting: each job acquires and releases
+// duPool only around its own directory listing, from inside du().
+func duSubmit(jobs []duJob, encKeyDB map[string][]prefixSSEPair) ([]duStats, [][]duMessage, error) {
+	resCh := make(chan duResult, len(jobs))
+	for _, job := range jobs {
+		job := job
+		go func() {
+			stats, msgs, err := du(job.alias, job.depth, encKeyDB)
+			resCh <- duResult{index: job.index, stats: stats, msgs: msgs, err: err}
+		}()
+	}
+
+	stats := make([]duStats, len(jobs))
+	msgs := make([][]duMessage, len(jobs))
+	var firstErr error
+	for range jobs {
+		res := <-resCh
+		if res.err != nil && firstErr == nil {
+			firstErr = res.err
+		}
+		stats[res.index] = res.stats
+		msgs[res.index] = res.msgs
+	}
+	return stats, msgs, firstErr
+}
+
 // Summarize disk usage.
 var duCmd = cli.Command{
 	Name:   "du",
@@ -64,20 +243,52 @@ EXAMPLES:
 
    2. Summarize disk usage of 'louis' prefix in 'jazz-songs' bucket upto two levels.
       {{.Prompt}} {{.HelpName}} --depth=2 s3/jazz-songs/louis/
+
+   3. Summarize on-disk block usage of an HDFS alias instead of logical file size.
+      {{.Prompt}} {{.HelpName}} --apparent-size=false myhdfs/jazz-songs/
+
+   4. Summarize disk usage of only the '*.wav' objects in 'jazz-songs', ignoring anything under 'tmp/'.
+      {{.Prompt}} {{.HelpName}} --include="*.wav" --exclude="tmp/*" s3/jazz-songs
+
+   5. Break down disk usage of a lifecycle-managed bucket by storage class, including old versions.
+      {{.Prompt}} {{.HelpName}} --versions --by-class s3/jazz-songs
 `,
 }
 
+// duVersionsMessage is the --versions breakdown of a duMessage.
+type duVersionsMessage struct {
+	Current       string `json:"current"`
+	NonCurrent    string `json:"nonCurrent"`
+	DeleteMarkers int64  `json:"deleteMarkers"`
+}
+
 // Structured message depending on the type of console.
 type duMessage struct {
-	Prefix string `json:"prefix"`
-	Size   string `json:"size"`
-	Status string `json:"status"`
+	Prefix   string             `json:"prefix"`
+	Size     string             `json:"size"`
+	Status   string             `json:"status"`
+	Classes  map[string]string  `json:"classes,omitempty"`
+	Versions *duVersionsMessage `json:"versions,omitempty"`
 }
 
-// Colorized message for console printing.
+// Colorized message for console printing. When --by-class was given,
+// one additional indented line is printed per storage class below the
+// prefix's total.
 func (r duMessage) String() string {
-	return fmt.Sprintf("%s\t%s", console.Colorize("Size", r.Size),
-		console.Colorize("Prefix", r.Prefix))
+	lines := []string{fmt.Sprintf("%s\t%s", console.Colorize("Size", r.Size),
+		console.Colorize("Prefix", r.Prefix))}
+
+	classes := make([]string, 0, len(r.Classes))
+	for class := range r.Classes {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		lines = append(lines, fmt.Sprintf("  %s\t%s",
+			console.Colorize("Size", r.Classes[class]), console.Colorize("Prefix", class)))
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 // JSON'ified message for scripting.
@@ -87,7 +298,126 @@ func (r duMessage) JSON() string {
 	return string(msgBytes)
 }
 
-func du(urlStr string, depth int, encKeyDB map[string][]prefixSSEPair) (int64, error) {
+// duPool bounds how many directory listings are in flight at once for
+// the current `du` invocation. It is nil when running with
+// --parallel=1, in which case du() recurses in-line exactly as before.
+var duPool *duTreeWalkPool
+
+// duApparentSize reports the logical file size when true (the
+// default). When false, content from a backend that reports a block
+// size (currently only hdfs, via its BlockSize field) is counted as its
+// size rounded up to a whole number of blocks, matching `du
+// --apparent-size=false` on a real filesystem.
+var duApparentSize = true
+
+// duInclude/duExclude are the --include/--exclude glob patterns for
+// the current invocation, read from the flags once in mainDu and
+// consulted by every recursive du() call. They are kept as pattern
+// strings; wildcard.Match evaluates them afresh for each object.
+var (
+	duInclude []string
+	duExclude []string
+)
+
+// duByClass/duVersions switch on the --by-class and --versions
+// breakdowns for the current invocation. duVersions only changes how
+// stats.addFile accounts for what List already returned on content's
+// IsLatest/IsDeleteMarker fields; it does not itself change what the
+// backend includes in the listing, so it only reports a real breakdown
+// against a backend that satisfies duVersionsLister below. See also
+// duVersionsWarned.
+var (
+	duByClass  bool
+	duVersions bool
+)
+
+// duVersionsWarned makes sure the duVersionsLister warning below fires
+// at most once per `du` invocation, no matter how many directories (or,
+// with --parallel, how many concurrent du() calls) end up checking it.
+var duVersionsWarned bool
+
+// duVersionsLister is implemented by client.Client backends that can
+// confirm their List/ListRecursive output actually walks every version
+// of an object rather than just the current one (no backend in this
+// chunk of the tree does yet). Without it, du has no way to tell
+// whether content.IsLatest/IsDeleteMarker on what List already
+// returned reflects a real multi-version listing or just the single
+// current object every backend here returns today — the same
+// optional-capability pattern ls.go's objectLockGetter/detectShowLock
+// uses for object-lock, applied to a capability this chunk can only
+// probe for, not itself provide.
+type duVersionsLister interface {
+	ListsAllVersions() bool
+}
+
+// warnIfVersionsUnsupported prints a one-time warning when --versions
+// was requested against a backend that cannot confirm it actually lists
+// every version, so NonCurrent/DeleteMarkers reading zero is understood
+// as "this backend never reported any" rather than mistaken for "this
+// prefix truly has none."
+func warnIfVersionsUnsupported(clnt interface{}) {
+	if !duVersions || duVersionsWarned {
+		return
+	}
+	duVersionsWarned = true
+	if vl, ok := clnt.(duVersionsLister); ok && vl.ListsAllVersions() {
+		return
+	}
+	err := fmt.Errorf("--versions was requested, but this backend's listing does not report non-current versions or delete markers; NonCurrent/DeleteMarkers will read zero")
+	errorIf(probe.NewError(err), "Disk usage versions breakdown may be incomplete.")
+}
+
+// duRootPath is the path component of the top-level target passed to
+// `du`, used to compute the key a content's --include/--exclude
+// patterns are matched against, relative to that target rather than
+// to whatever subdirectory happens to be walking it.
+var duRootPath string
+
+// duMatchesFilter reports whether a key relative to duRootPath should
+// be counted, honoring duInclude/duExclude: it must match at least one
+// include pattern (if any were given) and none of the exclude
+// patterns.
+func duMatchesFilter(path string) bool {
+	// path (content.URL.Path) retains its leading slash; duRootPath
+	// (set in mainDu via strings.Trim(u.Path, "/")) does not. Strip the
+	// leading slash first so the TrimPrefix below actually has a chance
+	// to match, the same slash-aware trimming ls.go's doListRecursive
+	// already does for the same reason.
+	key := strings.TrimPrefix(path, "/")
+	key = strings.TrimPrefix(key, duRootPath)
+	key = strings.TrimPrefix(key, "/")
+	for _, pattern := range duExclude {
+		if wildcard.Match(pattern, key) {
+			return false
+		}
+	}
+	if len(duInclude) == 0 {
+		return true
+	}
+	for _, pattern := range duInclude {
+		if wildcard.Match(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// du walks urlStr recursively and returns its total size together with
+// the duMessages that should be printed for it and its descendants, in
+// the same depth-first order a serial walk would have produced. The
+// caller is responsible for flushing them (see mainDu), which keeps
+// output deterministic even when subdirectories are walked out of
+// order by the pool.
+//
+// When duPool is set, it is only ever held across this directory's own
+// listing below, never across the recursive calls that walk its
+// subdirectories: those run as ordinary goroutines (via duSubmit) that
+// acquire their own slot when their turn to list comes up. A du() that
+// held its slot while also waiting on its children would let enough
+// concurrently-running directories starve the whole pool; releasing it
+// first means there is always a free slot for some descendant to make
+// progress.
+func du(urlStr string, depth int, encKeyDB map[string][]prefixSSEPair) (duStats, []duMessage, error) {
 	targetAlias, targetURL, _ := mustExpandAlias(urlStr)
 	if !strings.HasSuffix(targetURL, "/") {
 		targetURL += "/"
@@ -96,40 +426,106 @@ func du(urlStr string, depth int, encKeyDB map[string][]prefixSSEPair) (int64, e
 	clnt, pErr := newClientFromAlias(targetAlias, targetURL)
 	if pErr != nil {
 		errorIf(pErr.Trace(urlStr), "Failed to summarize disk usage `"+urlStr+"`.")
-		return 0, exitStatus(globalErrorExitStatus) // End of journey.
+		return duStats{}, nil, exitStatus(globalErrorExitStatus) // End of journey.
+	}
+	warnIfVersionsUnsupported(clnt)
+
+	if duPool != nil {
+		duPool.acquire()
 	}
 
 	isRecursive := false
 	isIncomplete := false
 	contentCh := clnt.List(isRecursive, isIncomplete, DirFirst)
-	size := int64(0)
-	for content := range contentCh {
-		if content.Err != nil {
-			errorIf(content.Err.Trace(urlStr), "Failed to find disk usage of `"+urlStr+"` recursively.")
-			return 0, exitStatus(globalErrorExitStatus)
-		}
+	var stats duStats
+	var msgs []duMessage
+	var subJobs []duJob
 
-		if content.URL.String() == targetURL {
-			continue
-		}
+	// A single timer, reset on every item received, stands in for a
+	// fresh time.After(duWalkTimeout) per item: contentCh can report
+	// thousands of items for one prefix, and a new timer per item would
+	// otherwise leak one until GC on every single one of them.
+	stallTimer := time.NewTimer(duWalkTimeout)
+	defer stallTimer.Stop()
+listing:
+	for {
+		select {
+		case content, ok := <-contentCh:
+			if !ok {
+				break listing
+			}
+			if !stallTimer.Stop() {
+				<-stallTimer.C
+			}
+			stallTimer.Reset(duWalkTimeout)
 
-		if content.Type.IsDir() {
-			depth := depth
-			if depth > 0 {
-				depth--
+			if content.Err != nil {
+				if duPool != nil {
+					duPool.release()
+				}
+				errorIf(content.Err.Trace(urlStr), "Failed to find disk usage of `"+urlStr+"` recursively.")
+				return duStats{}, nil, exitStatus(globalErrorExitStatus)
 			}
 
-			subDirAlias := content.URL.Path
-			if targetAlias != "" {
-				subDirAlias = targetAlias + "/" + content.URL.Path
+			if content.URL.String() == targetURL {
+				continue
 			}
-			used, err := du(subDirAlias, depth, encKeyDB)
-			if err != nil {
-				return 0, err
+
+			if content.Type.IsDir() {
+				subDepth := depth
+				if subDepth > 0 {
+					subDepth--
+				}
+
+				subDirAlias := content.URL.Path
+				if targetAlias != "" {
+					subDirAlias = targetAlias + "/" + content.URL.Path
+				}
+
+				if duPool == nil {
+					childStats, subMsgs, err := du(subDirAlias, subDepth, encKeyDB)
+					if err != nil {
+						return duStats{}, nil, err
+					}
+					stats.merge(childStats)
+					msgs = append(msgs, subMsgs...)
+					continue
+				}
+
+				subJobs = append(subJobs, duJob{index: len(subJobs), alias: subDirAlias, depth: subDepth})
+			} else {
+				if !duMatchesFilter(content.URL.Path) {
+					continue
+				}
+				fileSize := content.Size
+				if !duApparentSize && content.BlockSize > 0 {
+					blocks := (content.Size + content.BlockSize - 1) / content.BlockSize
+					fileSize = blocks * content.BlockSize
+				}
+				stats.addFile(fileSize, content.StorageClass, content.IsLatest, content.IsDeleteMarker)
+			}
+		case <-stallTimer.C:
+			if duPool != nil {
+				duPool.release()
 			}
-			size += used
-		} else {
-			size += content.Size
+			err := fmt.Errorf("directory listing of `%s` stalled for more than %s", urlStr, duWalkTimeout)
+			errorIf(probe.NewError(err), "Failed to summarize disk usage `"+urlStr+"`.")
+			return duStats{}, nil, exitStatus(globalErrorExitStatus)
+		}
+	}
+
+	if duPool != nil {
+		duPool.release()
+	}
+
+	if len(subJobs) > 0 {
+		childStats, subMsgs, err := duSubmit(subJobs, encKeyDB)
+		if err != nil {
+			return duStats{}, nil, err
+		}
+		for i, cs := range childStats {
+			stats.merge(cs)
+			msgs = append(msgs, subMsgs[i]...)
 		}
 	}
 
@@ -139,14 +535,31 @@ func du(urlStr string, depth int, encKeyDB map[string][]prefixSSEPair) (int64, e
 			panic(err)
 		}
 
-		printMsg(duMessage{
+		msg := duMessage{
 			Prefix: strings.Trim(u.Path, "/"),
-			Size:   strings.Join(strings.Fields(humanize.IBytes(uint64(size))), ""),
+			Size:   strings.Join(strings.Fields(humanize.IBytes(uint64(stats.Size))), ""),
 			Status: "success",
-		})
+		}
+
+		if duByClass && len(stats.Classes) > 0 {
+			msg.Classes = make(map[string]string, len(stats.Classes))
+			for class, size := range stats.Classes {
+				msg.Classes[class] = strings.Join(strings.Fields(humanize.IBytes(uint64(size))), "")
+			}
+		}
+
+		if duVersions {
+			msg.Versions = &duVersionsMessage{
+				Current:       strings.Join(strings.Fields(humanize.IBytes(uint64(stats.Current))), ""),
+				NonCurrent:    strings.Join(strings.Fields(humanize.IBytes(uint64(stats.NonCurrent))), ""),
+				DeleteMarkers: stats.DeleteMarkers,
+			}
+		}
+
+		msgs = append(msgs, msg)
 	}
 
-	return size, nil
+	return stats, msgs, nil
 }
 
 // main for du command.
@@ -164,12 +577,35 @@ func mainDu(ctx *cli.Context) error {
 		depth = -1
 	}
 
+	duApparentSize = ctx.BoolT("apparent-size")
+	duInclude = ctx.StringSlice("include")
+	duExclude = ctx.StringSlice("exclude")
+	duByClass = ctx.Bool("by-class")
+	duVersions = ctx.Bool("versions")
+
+	parallel := ctx.Int("parallel")
+	if parallel > 1 {
+		duPool = newDuTreeWalkPool(parallel)
+		defer func() {
+			duPool = nil
+		}()
+	}
+
 	// Set color.
 	console.SetColor("Remove", color.New(color.FgGreen, color.Bold))
 
 	var duErr error
 	for _, urlStr := range ctx.Args() {
-		if _, err := du(urlStr, depth, encKeyDB); duErr == nil {
+		_, rootURL, _ := mustExpandAlias(urlStr)
+		if u, err := url.Parse(rootURL); err == nil {
+			duRootPath = strings.Trim(u.Path, "/")
+		}
+
+		_, msgs, err := du(urlStr, depth, encKeyDB)
+		for _, m := range msgs {
+			printMsg(m)
+		}
+		if duErr == nil {
 			duErr = err
 		}
 	}
@@ -0,0 +1,45 @@
+/*
+ * MinIO Client (C) 2019 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import "testing"
+
+func TestDuMatchesFilter(t *testing.T) {
+	savedRoot, savedInclude, savedExclude := duRootPath, duInclude, duExclude
+	defer func() {
+		duRootPath, duInclude, duExclude = savedRoot, savedInclude, savedExclude
+	}()
+
+	duRootPath = "jazz-songs"
+	duInclude = []string{"*.wav"}
+	duExclude = []string{"tmp/*"}
+
+	cases := []struct {
+		path string
+		want bool
+	}{
+		{"/jazz-songs/louis/satchmo.wav", true},
+		{"/jazz-songs/louis/satchmo.mp3", false},
+		{"/jazz-songs/tmp/scratch.wav", false},
+		{"jazz-songs/louis/satchmo.wav", true},
+	}
+	for _, c := range cases {
+		if got := duMatchesFilter(c.path); got != c.want {
+			t.Errorf("duMatchesFilter(%q) = %v, want %v", c.path, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * MinIO Client (C) 2019 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDuTreeWalkPoolBounds verifies that at most `parallel` acquirers
+// hold a slot at once, and that every acquirer is eventually able to
+// proceed.
+func TestDuTreeWalkPoolBounds(t *testing.T) {
+	const parallel = 3
+	const total = 20
+
+	pool := newDuTreeWalkPool(parallel)
+
+	var mu sync.Mutex
+	inFlight := 0
+	maxInFlight := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.acquire()
+			defer pool.release()
+
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > parallel {
+		t.Fatalf("observed %d concurrent holders, pool only allows %d", maxInFlight, parallel)
+	}
+}
+
+// TestDuTreeWalkPoolNoSelfDeadlock reproduces the shape of du()'s own
+// usage: a slot holder releases its slot before waiting on goroutines
+// that themselves need a slot. If acquire/release were instead held for
+// the duration of waiting on children (how a fixed worker pool wedges
+// itself, see duTreeWalkPool), this would hang with parallel < the
+// branching factor used here.
+func TestDuTreeWalkPoolNoSelfDeadlock(t *testing.T) {
+	const parallel = 2
+	pool := newDuTreeWalkPool(parallel)
+
+	var walk func(depth int)
+	walk = func(depth int) {
+		pool.acquire()
+		pool.release()
+
+		if depth == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < parallel+1; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				walk(depth - 1)
+			}()
+		}
+		wg.Wait()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		walk(3)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("walk did not complete, pool likely deadlocked")
+	}
+}
@@ -25,7 +25,9 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/minio/mc/pkg/client"
 	"github.com/minio/mc/pkg/console"
+	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio/pkg/iodine"
+	"github.com/minio/minio/pkg/wildcard"
 )
 
 /// LS - related internal functions
@@ -35,8 +37,39 @@ const (
 	printDate = "2006-01-02 15:04:05 MST"
 )
 
-// printContent prints content meta-data
-func printContent(date time.Time, v int64, name string, fileType os.FileMode) {
+// lockInfo carries the object-lock retention/legal-hold state for a
+// single listing entry. A nil *lockInfo means the lock columns are
+// off (lsShowLock is false); an entry with no retention configured
+// prints empty columns rather than being omitted.
+type lockInfo struct {
+	mode        string
+	retainUntil time.Time
+	legalHold   string
+}
+
+// printContent prints content meta-data. When lock is non-nil
+// (lsShowLock is on, from --lock or from detectShowLock), the retention
+// mode, retain-until date, and legal-hold state are printed as extra
+// columns ahead of the time/size/name columns already there.
+func printContent(date time.Time, v int64, name string, fileType os.FileMode, lock *lockInfo) {
+	if lock != nil {
+		retainUntil := "-"
+		if !lock.retainUntil.IsZero() {
+			retainUntil = lock.retainUntil.Local().Format(printDate)
+		}
+		mode := lock.mode
+		if mode == "" {
+			mode = "-"
+		}
+		legalHold := lock.legalHold
+		if legalHold == "" {
+			legalHold = "-"
+		}
+		fmt.Printf(console.Time("%-10s ", mode))
+		fmt.Printf(console.Time("%s ", retainUntil))
+		fmt.Printf(console.Time("%-3s ", legalHold))
+	}
+
 	fmt.Printf(console.Time("[%s] ", date.Local().Format(printDate)))
 	fmt.Printf(console.Size("%6s ", humanize.IBytes(uint64(v))))
 
@@ -54,7 +87,88 @@ func printContent(date time.Time, v int64, name string, fileType os.FileMode) {
 	}
 }
 
-// doList - list all entities inside a folder
+// contentLockInfo extracts the object-lock retention/legal-hold state
+// mc's client populated on content (from the S3 `x-amz-object-lock-*`
+// response headers) when showLock is set.
+func contentLockInfo(c *client.Content, showLock bool) *lockInfo {
+	if !showLock {
+		return nil
+	}
+	return &lockInfo{
+		mode:        c.RetentionMode,
+		retainUntil: c.RetentionUntil,
+		legalHold:   c.LegalHold,
+	}
+}
+
+// objectLockGetter is implemented by client.Client backends that can
+// report whether their bucket has object-lock enabled (currently just
+// the s3 backend). Backends that don't implement it (fs, hdfs, ...)
+// are simply treated as lock-less by detectShowLock below, the same
+// optional-capability pattern mc already uses elsewhere for behavior
+// only some backends support.
+type objectLockGetter interface {
+	GetObjectLockConfiguration() (enabled bool, err *probe.Error)
+}
+
+// detectShowLock auto-detects whether clnt's bucket has object-lock
+// enabled, so a caller can decide whether to show the lock columns
+// without requiring the user to pass --lock explicitly every time
+// against a bucket that already has it on. Any error probing the
+// configuration (including the bucket simply not being lock-enabled)
+// is treated as "don't show lock columns" rather than surfaced, since
+// this is a best-effort convenience, not the user's explicit request.
+func detectShowLock(clnt client.Client) bool {
+	lg, ok := clnt.(objectLockGetter)
+	if !ok {
+		return false
+	}
+	enabled, err := lg.GetObjectLockConfiguration()
+	if err != nil {
+		return false
+	}
+	return enabled
+}
+
+// lsInclude/lsExclude are the --include/--exclude glob patterns, and
+// lsShowLock switches on the object-lock columns, for the current `ls`
+// invocation. They are package-level rather than threaded through
+// doList/doListRecursive as extra parameters, so that this chunk does
+// not have to change the signature of functions whose call sites
+// (mainList, in cmd/ls-main.go) live outside it. Whichever command
+// sets up the listing is expected to assign these once, from its flag
+// parsing (and, for lsShowLock, optionally detectShowLock), before
+// calling doList or doListRecursive.
+var (
+	lsInclude  []string
+	lsExclude  []string
+	lsShowLock bool
+)
+
+// matchesFilter reports whether name should be listed given a set of
+// --include/--exclude glob patterns: it must match at least one
+// include pattern (if any were given) and none of the exclude
+// patterns. name is matched relative to the target prefix, the same
+// way MinIO's wildcard package matches object keys elsewhere in mc.
+func matchesFilter(name string, include, exclude []string) bool {
+	for _, pattern := range exclude {
+		if wildcard.Match(pattern, name) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if wildcard.Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// doList - list all entities inside a folder, honoring lsInclude/
+// lsExclude/lsShowLock.
 func doList(clnt client.Client, targetURL string) error {
 	var err error
 	for contentCh := range clnt.List() {
@@ -62,7 +176,11 @@ func doList(clnt client.Client, targetURL string) error {
 			err = contentCh.Err
 			break
 		}
-		printContent(contentCh.Content.Time, contentCh.Content.Size, contentCh.Content.Name, contentCh.Content.Type)
+		if !matchesFilter(contentCh.Content.Name, lsInclude, lsExclude) {
+			continue
+		}
+		printContent(contentCh.Content.Time, contentCh.Content.Size, contentCh.Content.Name, contentCh.Content.Type,
+			contentLockInfo(contentCh.Content, lsShowLock))
 	}
 	if err != nil {
 		return iodine.New(err, map[string]string{"Target": targetURL})
@@ -70,7 +188,8 @@ func doList(clnt client.Client, targetURL string) error {
 	return nil
 }
 
-// doListRecursive - list all entities inside folders and sub-folders recursively
+// doListRecursive - list all entities inside folders and sub-folders
+// recursively, honoring lsInclude/lsExclude/lsShowLock.
 func doListRecursive(clnt client.Client, targetURL string) error {
 	var err error
 	for contentCh := range clnt.ListRecursive() {
@@ -83,10 +202,42 @@ func doListRecursive(clnt client.Client, targetURL string) error {
 		//
 		// To be consistent we have to filter them out
 		contentName := strings.TrimPrefix(contentCh.Content.Name, strings.TrimSuffix(targetURL, "/")+"/")
-		printContent(contentCh.Content.Time, contentCh.Content.Size, contentName, contentCh.Content.Type)
+		if !matchesFilter(contentName, lsInclude, lsExclude) {
+			continue
+		}
+		printContent(contentCh.Content.Time, contentCh.Content.Size, contentName, contentCh.Content.Type,
+			contentLockInfo(contentCh.Content, lsShowLock))
 	}
 	if err != nil {
 		return iodine.New(err, map[string]string{"Target": targetURL})
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// contentLockMessage is the object-lock portion of a listing entry's
+// JSON representation. Its three fields are populated whenever lock is
+// non-nil (lsShowLock was on for this invocation) regardless of
+// whether any of them actually hold a value, so scripts consuming
+// `--json` output don't have to special-case "--lock was passed but
+// this object has no retention configured" any differently than the
+// colorized columns already do for console output.
+type contentLockMessage struct {
+	Mode        string `json:"mode"`
+	RetainUntil string `json:"retainUntil"`
+	LegalHold   string `json:"legalHold"`
+}
+
+// newContentLockMessage builds the JSON lock payload for an entry, or
+// nil when lock is nil (lsShowLock was off for this invocation) so it
+// can be embedded with `json:",omitempty"` on whatever per-entry
+// message type mainList's --json path marshals.
+func newContentLockMessage(lock *lockInfo) *contentLockMessage {
+	if lock == nil {
+		return nil
+	}
+	msg := &contentLockMessage{Mode: lock.mode, LegalHold: lock.legalHold}
+	if !lock.retainUntil.IsZero() {
+		msg.RetainUntil = lock.retainUntil.Local().Format(printDate)
+	}
+	return msg
+}
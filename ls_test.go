@@ -0,0 +1,81 @@
+/*
+ * Minio Client (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/client"
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestMatchesFilter(t *testing.T) {
+	cases := []struct {
+		name             string
+		include, exclude []string
+		want             bool
+	}{
+		{"song.wav", []string{"*.wav"}, nil, true},
+		{"song.mp3", []string{"*.wav"}, nil, false},
+		{"tmp/song.wav", nil, []string{"tmp/*"}, false},
+		{"song.wav", nil, nil, true},
+	}
+	for _, c := range cases {
+		if got := matchesFilter(c.name, c.include, c.exclude); got != c.want {
+			t.Errorf("matchesFilter(%q, %v, %v) = %v, want %v", c.name, c.include, c.exclude, got, c.want)
+		}
+	}
+}
+
+type lockGetterClient struct {
+	client.Client
+	enabled bool
+	err     *probe.Error
+}
+
+func (l lockGetterClient) GetObjectLockConfiguration() (bool, *probe.Error) {
+	return l.enabled, l.err
+}
+
+func TestDetectShowLock(t *testing.T) {
+	if detectShowLock(lockGetterClient{enabled: true}) != true {
+		t.Error("expected detectShowLock to report true when the backend says lock is enabled")
+	}
+	if detectShowLock(lockGetterClient{enabled: false}) != false {
+		t.Error("expected detectShowLock to report false when the backend says lock is disabled")
+	}
+	if detectShowLock(lockGetterClient{enabled: true, err: probe.NewError(errors.New("object-lock probe failed"))}) != false {
+		t.Error("expected detectShowLock to report false when the backend errors")
+	}
+}
+
+func TestNewContentLockMessage(t *testing.T) {
+	if msg := newContentLockMessage(nil); msg != nil {
+		t.Errorf("newContentLockMessage(nil) = %+v, want nil", msg)
+	}
+
+	retainUntil := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := newContentLockMessage(&lockInfo{mode: "GOVERNANCE", retainUntil: retainUntil, legalHold: "ON"})
+	if msg == nil {
+		t.Fatal("newContentLockMessage returned nil for a non-nil lockInfo")
+	}
+	if msg.Mode != "GOVERNANCE" || msg.LegalHold != "ON" || msg.RetainUntil == "" {
+		t.Errorf("newContentLockMessage populated %+v unexpectedly", msg)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * MinIO Client (C) 2019 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hdfs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/client"
+)
+
+// fakeFileInfo is a minimal os.FileInfo, standing in for what
+// hdfs.Client.ReadDir/Stat would otherwise return; this lets toContent
+// and path be exercised without a real namenode to connect to, which
+// is the most this chunk can offer in place of a true dockerized
+// integration test against HDFS.
+type fakeFileInfo struct {
+	name  string
+	size  int64
+	mtime time.Time
+	dir   bool
+}
+
+func (f fakeFileInfo) Name() string       { return f.name }
+func (f fakeFileInfo) Size() int64        { return f.size }
+func (f fakeFileInfo) Mode() os.FileMode  { return 0 }
+func (f fakeFileInfo) ModTime() time.Time { return f.mtime }
+func (f fakeFileInfo) IsDir() bool        { return f.dir }
+func (f fakeFileInfo) Sys() interface{}   { return nil }
+
+func TestHdfsClientPath(t *testing.T) {
+	cases := []struct {
+		hostPath string
+		want     string
+	}{
+		{"/jazz-songs/louis", "/jazz-songs/louis"},
+		{"jazz-songs/louis", "/jazz-songs/louis"},
+		{"/", "/"},
+	}
+	for _, c := range cases {
+		cl := &hdfsClient{hostURL: &client.URL{Path: c.hostPath}}
+		if got := cl.path(); got != c.want {
+			t.Errorf("path() with hostURL.Path=%q = %q, want %q", c.hostPath, got, c.want)
+		}
+	}
+}
+
+func TestHdfsClientToContent(t *testing.T) {
+	cl := &hdfsClient{hostURL: &client.URL{Path: "/jazz-songs"}}
+	mtime := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	fi := fakeFileInfo{name: "satchmo.wav", size: 1024, mtime: mtime}
+
+	content := cl.toContent("hdfs://namenode/jazz-songs/satchmo.wav", fi)
+	if content.Name != "satchmo.wav" {
+		t.Errorf("Name = %q, want satchmo.wav", content.Name)
+	}
+	if content.Size != 1024 {
+		t.Errorf("Size = %d, want 1024", content.Size)
+	}
+	if !content.Time.Equal(mtime) {
+		t.Errorf("Time = %v, want %v", content.Time, mtime)
+	}
+	// fakeFileInfo isn't *hdfs.FileInfo, so BlockSize is left at its
+	// zero value; only a real hdfs.FileInfo populates it.
+	if content.BlockSize != 0 {
+		t.Errorf("BlockSize = %d, want 0 for a non-hdfs.FileInfo", content.BlockSize)
+	}
+	if content.URL == nil || content.URL.String() != "hdfs://namenode/jazz-songs/satchmo.wav" {
+		t.Errorf("URL = %v, want hdfs://namenode/jazz-songs/satchmo.wav", content.URL)
+	}
+}